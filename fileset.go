@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/xyproto/files"
+)
+
+// FileSet wraps a set of Findings with a pluggable Ignorer, decoupling
+// ignore policy from how the tree is walked. Callers can substitute
+// NoopIgnorer, GitIgnorer, or their own implementation (handy in tests).
+type FileSet struct {
+	*Findings
+	root    string
+	ignorer Ignorer
+}
+
+// NewFileSet creates an empty FileSet rooted at root, using ignorer to
+// decide what gets excluded.
+func NewFileSet(root string, ignorer Ignorer) *FileSet {
+	if ignorer == nil {
+		ignorer = NoopIgnorer{}
+	}
+	return &FileSet{
+		Findings: NewFindings(),
+		root:     root,
+		ignorer:  ignorer,
+	}
+}
+
+// All returns every file this FileSet has collected that was not ignored.
+func (fs *FileSet) All() []string {
+	return fs.regularFiles
+}
+
+// RecursiveListFiles walks dir, consulting fs.ignorer at every step, and
+// returns the paths of all files that were not ignored. It also refreshes
+// fs.regularFiles/ignoredFiles so subsequent calls to All() reflect the
+// scan.
+func (fs *FileSet) RecursiveListFiles(dir string) ([]string, error) {
+	if !files.IsDir(dir) {
+		return nil, os.ErrNotExist
+	}
+
+	fs.mut.Lock()
+	fs.regularFiles = fs.regularFiles[:0]
+	fs.ignoredFiles = fs.ignoredFiles[:0]
+	fs.mut.Unlock()
+
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if fi.IsDir() {
+			ignored, err := fs.ignorer.IgnoreDirectory(path)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				fs.mut.Lock()
+				fs.ignoredFiles = append(fs.ignoredFiles, path)
+				fs.mut.Unlock()
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ignored, err := fs.ignorer.IgnoreFile(path)
+		if err != nil {
+			return err
+		}
+		fs.mut.Lock()
+		if ignored {
+			fs.ignoredFiles = append(fs.ignoredFiles, path)
+		} else {
+			fs.regularFiles = append(fs.regularFiles, path)
+		}
+		fs.infoMap[path] = fi
+		fs.mut.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs.All(), nil
+}
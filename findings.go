@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -35,16 +37,97 @@ func SplitPath(path string) []string {
 	return strings.Split(path, string(filepath.Separator))
 }
 
+// ExamineOptions configures Examine. A zero-value ExamineOptions is valid:
+// Workers defaults to runtime.GOMAXPROCS(0) and MaxDepth defaults to
+// unlimited.
+type ExamineOptions struct {
+	RespectIgnoreFiles bool
+	RespectHiddenFiles bool
+	MaxDepth           int // <= 0 means unlimited
+	Workers            int // <= 0 means runtime.GOMAXPROCS(0)
+}
+
+// walkEntry is one classified entry handed off from the walk callback to a
+// worker.
+type walkEntry struct {
+	path     string
+	fileInfo os.FileInfo
+	ignored  bool
+}
+
+// workerResult is a worker's private accumulation, merged into Findings
+// once all workers have drained the entry channel.
+type workerResult struct {
+	regularFiles []string
+	ignoredFiles []string
+	infoMap      map[string]os.FileInfo
+}
+
+// skipIfDir tells cwalk to prune an entire subtree once its root has been
+// classified as ignored/hidden, so descendants don't need to be classified
+// (and re-recorded) individually.
+func skipIfDir(fileInfo os.FileInfo) error {
+	if fileInfo.IsDir() {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
 func Examine(path string, respectIgnoreFiles, respectHiddenFiles bool, maxDepth int) (*Findings, error) {
+	return ExamineWithOptions(path, ExamineOptions{
+		RespectIgnoreFiles: respectIgnoreFiles,
+		RespectHiddenFiles: respectHiddenFiles,
+		MaxDepth:           maxDepth,
+	})
+}
+
+// ExamineWithOptions walks path and classifies every entry into regular or
+// ignored files. Entries are classified by the walk callback (which cwalk
+// itself calls from many goroutines) and handed off over a channel to a
+// fixed-size pool of workers, each of which accumulates into a private
+// slice; the slices are merged into the returned Findings once, after all
+// workers are done. This avoids spawning a goroutine per file and contending
+// on a single mutex, which is what the previous implementation did.
+func ExamineWithOptions(path string, opts ExamineOptions) (*Findings, error) {
 	if !files.IsDir(path) {
 		return nil, fmt.Errorf("not a path: %s", path)
 	}
 
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = math.MaxInt
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	root := path
 	findings := NewFindings()
+	ignorer := NewGitIgnorer(root) // compiles each ignore file once and caches it
 
-	var ignoreMut sync.Mutex
-	var extraIgnoredFiles []string
-	var wg sync.WaitGroup
+	entries := make(chan walkEntry, workers*64)
+	results := make([]workerResult, workers)
+	var workersWG sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		results[i].infoMap = make(map[string]os.FileInfo)
+		w := &results[i]
+		workersWG.Add(1)
+		go func() {
+			defer workersWG.Done()
+			for e := range entries {
+				if e.ignored {
+					w.ignoredFiles = append(w.ignoredFiles, e.path)
+				} else {
+					w.regularFiles = append(w.regularFiles, e.path)
+				}
+				w.infoMap[e.path] = e.fileInfo
+			}
+		}()
+	}
+
+	var gitMut sync.Mutex
 
 	walkFunc := func(path string, fileInfo os.FileInfo, err error) error {
 		if err != nil {
@@ -61,134 +144,62 @@ func Examine(path string, respectIgnoreFiles, respectHiddenFiles bool, maxDepth
 		}
 		head := strings.ToLower(parts[0])
 		if head == "vendor" {
-			if respectIgnoreFiles {
-				// Store the ignored file
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					findings.mut.Lock()
-					findings.ignoredFiles = append(findings.ignoredFiles, path)
-					findings.infoMap[path] = fileInfo
-					findings.mut.Unlock()
-				}()
-			} else {
-				// Store a regular file
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					findings.mut.Lock()
-					findings.regularFiles = append(findings.regularFiles, path)
-					findings.infoMap[path] = fileInfo
-					findings.mut.Unlock()
-				}()
-			}
-			return nil // skip
+			entries <- walkEntry{path: path, fileInfo: fileInfo, ignored: opts.RespectIgnoreFiles}
+			return skipIfDir(fileInfo) // prune the whole subtree, not just this entry
 		}
 		if head == ".git" {
-			if respectIgnoreFiles {
-				// Store the ignored file
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					findings.mut.Lock()
-					findings.ignoredFiles = append(findings.ignoredFiles, path)
-					findings.infoMap[path] = fileInfo
-					findings.mut.Unlock()
-				}()
-			} else {
-				// Store a regular file
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					findings.mut.Lock()
-					findings.regularFiles = append(findings.regularFiles, path)
-					findings.infoMap[path] = fileInfo
-					findings.mut.Unlock()
-				}()
-			}
-			// Find and store Git info
-			foundGit := false
-			findings.mut.Lock()
-			foundGit = findings.git != nil
-			findings.mut.Unlock()
+			entries <- walkEntry{path: path, fileInfo: fileInfo, ignored: opts.RespectIgnoreFiles}
+			gitMut.Lock()
+			foundGit := findings.git != nil
 			if !foundGit {
-				wg.Add(1)
-				go func() {
-					defer wg.Done()
-					git, err := NewGit(head) // pass in the path to the .git directory
-					if err != nil {
-						return // don't store the git struct in the findings
-					}
-					findings.mut.Lock()
+				// Find and store Git info. This runs in the walk callback,
+				// under gitMut, for the first .git entry any of cwalk's
+				// goroutines sees (and again only if NewGit failed), not in
+				// the pool workers.
+				if git, err := NewGit(head); err == nil { // pass in the path to the .git directory
 					findings.git = git
-					findings.mut.Unlock()
-				}()
+				}
 			}
-			return nil // skip
+			gitMut.Unlock()
+			return skipIfDir(fileInfo) // prune the whole subtree, not just this entry
 		}
-		if respectHiddenFiles && len(head) > 1 && strings.HasPrefix(head, ".") {
-			// Store the ignored file
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				findings.mut.Lock()
-				findings.ignoredFiles = append(findings.ignoredFiles, path)
-				findings.infoMap[path] = fileInfo
-				findings.mut.Unlock()
-			}()
-			return nil // skip
+		if opts.RespectHiddenFiles && IsHidden(path, fileInfo) {
+			entries <- walkEntry{path: path, fileInfo: fileInfo, ignored: true}
+			return skipIfDir(fileInfo) // descendants of a hidden directory are hidden too
 		}
-		if respectIgnoreFiles && (head == ".ignore" || head == ".gitignore") {
-			if extraIgnoredFilesData, err := os.ReadFile(head); err == nil { // success
-				lines := strings.Split(string(extraIgnoredFilesData), "\n")
-				for _, line := range lines {
-					trimmedLine := strings.TrimSpace(line)
-					if trimmedLine != "" && !strings.HasPrefix(trimmedLine, "#") {
-						ignoreMut.Lock()
-						extraIgnoredFiles = append(extraIgnoredFiles, trimmedLine)
-						ignoreMut.Unlock()
-					}
-				}
+		if opts.RespectIgnoreFiles {
+			var ignored bool
+			var ignoreErr error
+			if fileInfo.IsDir() {
+				ignored, ignoreErr = ignorer.IgnoreDirectory(path)
+			} else {
+				ignored, ignoreErr = ignorer.IgnoreFile(path)
+			}
+			if ignoreErr != nil {
+				return ignoreErr
+			}
+			if ignored {
+				entries <- walkEntry{path: path, fileInfo: fileInfo, ignored: true}
+				return skipIfDir(fileInfo) // matched a .gitignore/.ignore pattern; prune its subtree too
 			}
 		}
-		if respectHiddenFiles && len(head) > 1 && strings.HasPrefix(head, ".") {
-			ignoreMut.Lock()
-			extraIgnoredFiles = append(extraIgnoredFiles, head)
-			ignoreMut.Unlock()
-		}
-		// Store a regular file
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			findings.mut.Lock()
-			findings.regularFiles = append(findings.regularFiles, path)
-			findings.infoMap[path] = fileInfo
-			findings.mut.Unlock()
-		}()
+		entries <- walkEntry{path: path, fileInfo: fileInfo}
 		return nil // all good
 	}
 
-	if err := cwalk.Walk(path, walkFunc); err != nil {
-		return nil, err
-	}
+	walkErr := cwalk.Walk(path, walkFunc)
+	close(entries)
+	workersWG.Wait()
 
-	hasS := func(xs []string, x string) (bool, int) {
-		for i, e := range xs {
-			if x == e {
-				return true, i
-			}
-		}
-		return false, -1
+	if walkErr != nil {
+		return nil, walkErr
 	}
 
-	wg.Wait() // The mutexes above are not needed after this point
-
-	for _, extraIgnoredFile := range extraIgnoredFiles {
-		if ok, index := hasS(findings.regularFiles, extraIgnoredFile); ok {
-			// delete extraIgnoredFile from findings.regular by appending two sliced string slices
-			findings.regularFiles = append(findings.regularFiles[:index], findings.regularFiles[index+1:]...)
-			// add extraIgnoredFile to findings.ignoredFiles
-			findings.ignoredFiles = append(findings.ignoredFiles, extraIgnoredFile)
+	for _, w := range results {
+		findings.regularFiles = append(findings.regularFiles, w.regularFiles...)
+		findings.ignoredFiles = append(findings.ignoredFiles, w.ignoredFiles...)
+		for k, v := range w.infoMap {
+			findings.infoMap[k] = v
 		}
 	}
 
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestExamineRespectsUnanchoredIgnorePatterns(t *testing.T) {
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, ".gitignore"), "*.log\nnode_modules\n")
+	mustWrite(t, filepath.Join(dir, "debug.log"), "x")
+	mustWriteDir(t, filepath.Join(dir, "node_modules"))
+	mustWrite(t, filepath.Join(dir, "node_modules", "pkg.js"), "x")
+	mustWrite(t, filepath.Join(dir, "main.go"), "package main")
+
+	findings, err := Examine(dir, true, false, 100)
+	if err != nil {
+		t.Fatalf("Examine: %v", err)
+	}
+
+	for _, regular := range findings.regularFiles {
+		if filepath.Base(regular) == "debug.log" {
+			t.Errorf("debug.log should have been ignored by *.log, found in regularFiles: %v", findings.regularFiles)
+		}
+		if filepath.Base(regular) == "pkg.js" {
+			t.Errorf("node_modules/pkg.js should have been ignored, found in regularFiles: %v", findings.regularFiles)
+		}
+	}
+}
+
+func TestExaminePrunesHiddenDirectorySubtree(t *testing.T) {
+	dir := t.TempDir()
+	mustWriteDir(t, filepath.Join(dir, ".vscode"))
+	mustWrite(t, filepath.Join(dir, ".vscode", "settings.json"), "{}")
+	mustWrite(t, filepath.Join(dir, "main.go"), "package main")
+
+	findings, err := Examine(dir, false, true, 100)
+	if err != nil {
+		t.Fatalf("Examine: %v", err)
+	}
+
+	for _, regular := range findings.regularFiles {
+		if filepath.Base(regular) == "settings.json" {
+			t.Errorf("settings.json under a hidden directory leaked into regularFiles: %v", findings.regularFiles)
+		}
+	}
+}
+
+func TestExamineRespectsIncludedIgnorePatterns(t *testing.T) {
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, "shared.gitignore"), "*.tmp\n")
+	mustWrite(t, filepath.Join(dir, ".gitignore"), "#include shared.gitignore\n")
+	mustWrite(t, filepath.Join(dir, "cache.tmp"), "x")
+	mustWrite(t, filepath.Join(dir, "main.go"), "package main")
+
+	findings, err := Examine(dir, true, false, 100)
+	if err != nil {
+		t.Fatalf("Examine: %v", err)
+	}
+
+	for _, regular := range findings.regularFiles {
+		if filepath.Base(regular) == "cache.tmp" {
+			t.Errorf("cache.tmp should have been ignored via the #include'd pattern, found in regularFiles: %v", findings.regularFiles)
+		}
+	}
+}
+
+func TestExamineIncludeCycleDoesNotHang(t *testing.T) {
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, "a.gitignore"), "#include b.gitignore\n*.a\n")
+	mustWrite(t, filepath.Join(dir, "b.gitignore"), "#include a.gitignore\n*.b\n")
+	mustWrite(t, filepath.Join(dir, ".gitignore"), "#include a.gitignore\n")
+	mustWrite(t, filepath.Join(dir, "keep.a"), "x")
+	mustWrite(t, filepath.Join(dir, "drop.a"), "x")
+
+	done := make(chan struct{})
+	var findings *Findings
+	var err error
+	go func() {
+		findings, err = Examine(dir, true, false, 100)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Examine did not return, #include cycle guard likely broken")
+	}
+	if err != nil {
+		t.Fatalf("Examine: %v", err)
+	}
+	for _, regular := range findings.regularFiles {
+		if filepath.Base(regular) == "drop.a" {
+			t.Errorf("drop.a should have been ignored via the cyclic #include chain, found in regularFiles: %v", findings.regularFiles)
+		}
+	}
+}
+
+func TestExamineZeroValueMaxDepthIsUnlimited(t *testing.T) {
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, "main.go"), "package main")
+
+	findings, err := ExamineWithOptions(dir, ExamineOptions{RespectIgnoreFiles: true})
+	if err != nil {
+		t.Fatalf("Examine: %v", err)
+	}
+	if len(findings.regularFiles) == 0 {
+		t.Fatalf("expected at least main.go with the zero-value MaxDepth, got none")
+	}
+}
+
+func mustWrite(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile(%s): %v", path, err)
+	}
+}
+
+func mustWriteDir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("MkdirAll(%s): %v", path, err)
+	}
+}
+
+// BenchmarkExamine measures Examine's cost on a synthetic tree of ~100k
+// files, spread across 100 subdirectories, with the worker-pool
+// walker introduced to replace the previous per-file goroutine spawn.
+func BenchmarkExamine(b *testing.B) {
+	const (
+		numDirs     = 100
+		filesPerDir = 1000 // 100 * 1000 = 100k files
+	)
+	root := b.TempDir()
+	for d := 0; d < numDirs; d++ {
+		dir := filepath.Join(root, "dir"+strconv.Itoa(d))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			b.Fatalf("MkdirAll: %v", err)
+		}
+		for f := 0; f < filesPerDir; f++ {
+			path := filepath.Join(dir, "file"+strconv.Itoa(f)+".txt")
+			if err := os.WriteFile(path, nil, 0o644); err != nil {
+				b.Fatalf("WriteFile: %v", err)
+			}
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Examine(root, true, true, 100); err != nil {
+			b.Fatalf("Examine: %v", err)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ignorePattern is a single compiled line from a .gitignore/.ignore file.
+type ignorePattern struct {
+	re       *regexp.Regexp
+	negate   bool // leading "!"
+	dirOnly  bool // trailing "/"
+	anchored bool // contains a "/" before the last character, or starts with "/"
+}
+
+// ignoreFile holds the compiled patterns found in one .gitignore/.ignore,
+// along with the directory it was loaded from (patterns are always matched
+// relative to this directory).
+type ignoreFile struct {
+	dir      string
+	patterns []ignorePattern
+}
+
+// globToRegexp translates a single gitignore-style glob into a regular
+// expression anchored to the full (relative) path it is matched against.
+// It supports "**", "*", "?" and character classes. A leading "/" is not
+// treated as an anchor here; compilePattern strips it before calling this.
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			// "**" matches across directory separators
+			sb.WriteString(".*")
+			i++
+			// swallow a following slash so "**/" also matches zero dirs
+			if i+1 < len(runes) && runes[i+1] == '/' {
+				i++
+			}
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '.':
+			sb.WriteString(`\.`)
+		case c == '[':
+			// copy the character class verbatim
+			j := i
+			for j < len(runes) && runes[j] != ']' {
+				j++
+			}
+			if j < len(runes) {
+				sb.WriteString(string(runes[i : j+1]))
+				i = j
+			} else {
+				sb.WriteString(`\[`)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// compilePattern parses one non-empty, non-comment line from an ignore file.
+func compilePattern(line string) ignorePattern {
+	var p ignorePattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	// a leading "\!" or "\#" escapes a literal ! or # at the start
+	line = strings.TrimPrefix(line, "\\")
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	} else if strings.Contains(line, "/") {
+		p.anchored = true
+	}
+	if p.anchored {
+		p.re = globToRegexp(line)
+	} else {
+		// unanchored patterns may match at any depth: splice an optional
+		// "any directories deep" prefix onto the compiled regex directly,
+		// rather than feeding "(**/)?" back through the glob compiler
+		// (which would treat its parens and "?" as glob syntax).
+		inner := globToRegexp(line)
+		p.re = regexp.MustCompile(`^(?:.*/)?` + strings.TrimPrefix(inner.String(), "^"))
+	}
+	return p
+}
+
+// readIgnoreLines reads a single ignore-style file, skipping blank lines and
+// comments, and inlining "#include <relative-path>" directives (resolved
+// relative to the directory the including file lives in), following
+// syncthing's .stignore convention. visited guards against include cycles
+// and is keyed by absolute path.
+func readIgnoreLines(path string, visited map[string]bool) []string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	if visited[absPath] {
+		return nil
+	}
+	visited[absPath] = true
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	dir := filepath.Dir(path)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#include ") {
+			included := strings.TrimSpace(strings.TrimPrefix(trimmed, "#include "))
+			if !filepath.IsAbs(included) {
+				included = filepath.Join(dir, included)
+			}
+			lines = append(lines, readIgnoreLines(included, visited)...)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// loadIgnoreFile reads and compiles every pattern in dir/name, returning nil
+// if the file does not exist or contains no usable patterns.
+func loadIgnoreFile(dir, name string) *ignoreFile {
+	path := filepath.Join(dir, name)
+	lines := readIgnoreLines(path, make(map[string]bool))
+	if len(lines) == 0 {
+		return nil
+	}
+	ig := &ignoreFile{dir: dir}
+	for _, line := range lines {
+		ig.patterns = append(ig.patterns, compilePattern(line))
+	}
+	return ig
+}
+
+var (
+	globalExcludesPathOnce sync.Once
+	globalExcludesPathVal  string
+)
+
+// globalExcludesPath resolves Git's core.excludesFile, falling back to the
+// conventional ~/.config/git/ignore when it is not configured. The result is
+// process-wide and doesn't change while pal is running, so the underlying
+// "git config" subprocess is only ever spawned once, no matter how many
+// files or directories are checked against it.
+func globalExcludesPath() string {
+	globalExcludesPathOnce.Do(func() {
+		globalExcludesPathVal = resolveGlobalExcludesPath()
+	})
+	return globalExcludesPathVal
+}
+
+func resolveGlobalExcludesPath() string {
+	if out, err := exec.Command("git", "config", "--get", "core.excludesFile").Output(); err == nil {
+		if p := strings.TrimSpace(string(out)); p != "" {
+			if expanded, err := expandHome(p); err == nil {
+				return expanded
+			}
+			return p
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "git", "ignore")
+}
+
+// expandHome expands a leading "~" in path to the current user's home
+// directory.
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path, err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// matches reports whether relPath (relative to ig.dir, using "/" separators)
+// matches any pattern in this ignore file, returning the verdict of the last
+// matching pattern (negation included) and whether any pattern matched at
+// all.
+func (ig *ignoreFile) matches(relPath string, isDir bool) (ignored bool, matched bool) {
+	for _, p := range ig.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(relPath) {
+			matched = true
+			ignored = !p.negate
+		}
+	}
+	return ignored, matched
+}
+
+// ignoreStack is an ordered set of ignore files, from lowest to highest
+// precedence (global excludes first, then repo root, down to the directory
+// nearest the matched path).
+type ignoreStack []*ignoreFile
+
+// match applies the whole stack to path and reports whether path should be
+// ignored. path may be given relative to root (as cwalk hands paths to the
+// walk callback) or already absolute; it is resolved against root before
+// being compared with each ignoreFile's (absolute) dir.
+func (stack ignoreStack) match(root, path string, isDir bool) bool {
+	absPath := path
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(root, path)
+	}
+	ignored := false
+	for _, ig := range stack {
+		if ig == nil {
+			continue
+		}
+		rel, err := filepath.Rel(ig.dir, absPath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if verdict, matched := ig.matches(rel, isDir); matched {
+			ignored = verdict
+		}
+	}
+	return ignored
+}
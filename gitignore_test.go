@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestCompilePatternUnanchored(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.log", "debug.log", false, true},
+		{"*.log", "sub/dir/debug.log", false, true},
+		{"*.log", "debug.logfile", false, false},
+		{"node_modules", "node_modules", true, true},
+		{"node_modules", "a/b/node_modules", true, true},
+		{"node_modules", "node_modules_backup", true, false},
+	}
+	for _, c := range cases {
+		p := compilePattern(c.pattern)
+		if got := p.re.MatchString(c.path); got != c.want {
+			t.Errorf("compilePattern(%q).re.MatchString(%q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestCompilePatternAnchored(t *testing.T) {
+	p := compilePattern("/build")
+	if !p.anchored {
+		t.Fatalf("expected /build to be anchored")
+	}
+	if !p.re.MatchString("build") {
+		t.Errorf("expected /build to match top-level build")
+	}
+	if p.re.MatchString("sub/build") {
+		t.Errorf("expected /build to not match nested sub/build")
+	}
+}
+
+func TestCompilePatternDirOnlyAndNegation(t *testing.T) {
+	p := compilePattern("vendor/")
+	if !p.dirOnly {
+		t.Fatalf("expected trailing slash to mark dirOnly")
+	}
+
+	neg := compilePattern("!keep.log")
+	if !neg.negate {
+		t.Fatalf("expected leading ! to mark negate")
+	}
+	if !neg.re.MatchString("keep.log") {
+		t.Errorf("expected !keep.log pattern to still match keep.log (negation is applied by the caller)")
+	}
+}
+
+func TestIgnoreFileMatchesLastPatternWins(t *testing.T) {
+	ig := &ignoreFile{patterns: []ignorePattern{
+		compilePattern("*.log"),
+		compilePattern("!keep.log"),
+	}}
+	if ignored, matched := ig.matches("debug.log", false); !matched || !ignored {
+		t.Errorf("expected debug.log to be matched and ignored")
+	}
+	if ignored, matched := ig.matches("keep.log", false); !matched || ignored {
+		t.Errorf("expected keep.log to be matched and re-included by the negation")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsHidden reports whether the last element of path is a hidden file or
+// directory. On Unix-like systems this means a name starting with a dot.
+func IsHidden(path string, fi os.FileInfo) bool {
+	base := filepath.Base(path)
+	return len(base) > 1 && strings.HasPrefix(base, ".")
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// IsHidden reports whether the last element of path is a hidden file or
+// directory. On Windows this is an NTFS attribute (FILE_ATTRIBUTE_HIDDEN)
+// and is checked in addition to the conventional leading-dot convention.
+func IsHidden(path string, fi os.FileInfo) bool {
+	base := filepath.Base(path)
+	if len(base) > 1 && strings.HasPrefix(base, ".") {
+		return true
+	}
+	pointer, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	attributes, err := syscall.GetFileAttributes(pointer)
+	if err != nil {
+		return false
+	}
+	return attributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
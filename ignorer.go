@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Ignorer decides whether a given file or directory should be excluded from
+// a scan. It lets callers swap ignore policy independently of how the tree
+// is walked.
+type Ignorer interface {
+	IgnoreFile(path string) (bool, error)
+	IgnoreDirectory(path string) (bool, error)
+}
+
+// NoopIgnorer never ignores anything.
+type NoopIgnorer struct{}
+
+func (NoopIgnorer) IgnoreFile(path string) (bool, error) {
+	return false, nil
+}
+
+func (NoopIgnorer) IgnoreDirectory(path string) (bool, error) {
+	return false, nil
+}
+
+// cachedIgnoreFile remembers the modification time an ignoreFile was loaded
+// at, so GitIgnorer can tell when it needs to be re-parsed.
+type cachedIgnoreFile struct {
+	file    *ignoreFile
+	modTime time.Time
+}
+
+// GitIgnorer is an Ignorer backed by the repository's .gitignore/.ignore
+// files (plus the global excludes file and, if present, the repo-local
+// .git/info/exclude), rooted at a fixed directory.
+type GitIgnorer struct {
+	root  string
+	mu    sync.Mutex
+	cache map[string]*cachedIgnoreFile
+}
+
+// NewGitIgnorer creates a GitIgnorer rooted at root.
+func NewGitIgnorer(root string) *GitIgnorer {
+	return &GitIgnorer{
+		root:  root,
+		cache: make(map[string]*cachedIgnoreFile),
+	}
+}
+
+// loadCached loads dir/name, reusing the cached parse unless the file's
+// mtime has changed since it was last read.
+func (g *GitIgnorer) loadCached(dir, name string) *ignoreFile {
+	path := filepath.Join(dir, name)
+
+	info, err := os.Stat(path)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err != nil {
+		delete(g.cache, path)
+		return nil
+	}
+	if cached, ok := g.cache[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.file
+	}
+	ig := loadIgnoreFile(dir, name)
+	g.cache[path] = &cachedIgnoreFile{file: ig, modTime: info.ModTime()}
+	return ig
+}
+
+// stackFor builds the ignore stack that applies to path, serving every
+// per-directory ignore file from g's mtime-aware cache rather than
+// re-reading and recompiling it for each path checked.
+func (g *GitIgnorer) stackFor(path string) ignoreStack {
+	var stack ignoreStack
+	if global := g.loadCached(filepath.Dir(globalExcludesPath()), filepath.Base(globalExcludesPath())); global != nil {
+		stack = append(stack, global)
+	}
+	// the repo-local equivalent of the global excludes file: lowest
+	// precedence of the per-repo files, same as real Git. Its patterns are
+	// rooted at the repo root, not at .git/info, so the dir is corrected
+	// after loading.
+	if exclude := g.loadCached(filepath.Join(g.root, ".git", "info"), "exclude"); exclude != nil {
+		exclude.dir = g.root
+		stack = append(stack, exclude)
+	}
+
+	absPath := path
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(g.root, path)
+	}
+	dir := filepath.Dir(absPath)
+	rel, err := filepath.Rel(g.root, dir)
+	if err != nil {
+		rel = ""
+	}
+	dirs := []string{g.root}
+	if rel != "." && rel != "" {
+		cur := g.root
+		for _, part := range SplitPath(rel) {
+			cur = filepath.Join(cur, part)
+			dirs = append(dirs, cur)
+		}
+	}
+	for _, d := range dirs {
+		if ig := g.loadCached(d, ".gitignore"); ig != nil {
+			stack = append(stack, ig)
+		}
+		if ig := g.loadCached(d, ".ignore"); ig != nil {
+			stack = append(stack, ig)
+		}
+	}
+	return stack
+}
+
+func (g *GitIgnorer) IgnoreFile(path string) (bool, error) {
+	return g.stackFor(path).match(g.root, path, false), nil
+}
+
+func (g *GitIgnorer) IgnoreDirectory(path string) (bool, error) {
+	return g.stackFor(path).match(g.root, path, true), nil
+}